@@ -0,0 +1,69 @@
+package msparser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+var mapStringAnyType = reflect.TypeOf(map[string]any{})
+
+// decode populates target (a pointer to a struct or map) from rInput
+// using mapstructure, in place of the hand-rolled extract*/fill* helpers
+// this parser used to have. It accepts YAML's widened numeric types
+// (int64, float64, ...) for int fields, normalizes a bare scalar into
+// {"value": scalar} wherever the target is a map[string]any (step and
+// check parameters), and promotes a single object into a one-element
+// slice wherever the target is a slice (so a polymorphic field can be
+// written as either one object or an array of them).
+//
+// When strict is true, any key in rInput with no matching struct field
+// in target is reported as an error instead of silently ignored.
+func decode(rInput any, target any, strict bool) error {
+	var metadata mapstructure.Metadata
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Metadata:         &metadata,
+		Result:           target,
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			scalarToParametersHook,
+			singleToSliceHook,
+		),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(rInput); err != nil {
+		return errInvalidInputReason(err.Error())
+	}
+
+	if strict && len(metadata.Unused) > 0 {
+		return errInvalidInputReason(fmt.Sprintf("unknown field(s): %s", strings.Join(metadata.Unused, ", ")))
+	}
+
+	return nil
+}
+
+// scalarToParametersHook turns a bare scalar step/check parameter, e.g.
+// `delay: 100`, into the map form `delay: {value: 100}` that Step.Parameters
+// and Check.Parameters expect.
+func scalarToParametersHook(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if to == mapStringAnyType && from.Kind() != reflect.Map {
+		return map[string]any{"value": data}, nil
+	}
+	return data, nil
+}
+
+// singleToSliceHook lets a polymorphic array field (steps, filters,
+// conditions, endpoints, any, all) be written as a single object instead
+// of a one-element array.
+func singleToSliceHook(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if to.Kind() == reflect.Slice && from.Kind() == reflect.Map {
+		return []any{data}, nil
+	}
+	return data, nil
+}
@@ -0,0 +1,264 @@
+package msparser
+
+import (
+	"fmt"
+	"unicode"
+)
+
+var errExpressionSyntax = fmt.Errorf("parse: invalid condition expression")
+
+func errExpressionSyntaxAt(column int, reason string) error {
+	return fmt.Errorf("%w at column %d: %s", errExpressionSyntax, column, reason)
+}
+
+// parseConditionExpression parses a compact boolean-expression form of a
+// Condition, e.g. "!auth.valid && (role.admin || role.editor)", into the
+// same Condition tree that "any"/"all"/"source"+"checks" would produce.
+//
+// Grammar:
+//
+//	expr    := term ('||' term)*
+//	term    := unary ('&&' unary)*
+//	unary   := '!' unary | primary
+//	primary := IDENT | '(' expr ')'
+//
+// An IDENT names a condition under definitions.Conditions; since that
+// map holds a list per name (as do Steps and Filters), a single-item
+// list is used as-is and a multi-item list is wrapped in a Condition.All,
+// matching how that list behaves when it appears inline elsewhere.
+func parseConditionExpression(expression string, definitions *Definitions) (*Condition, error) {
+	p := &exprParser{lexer: newExprLexer(expression), definitions: definitions}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	condition, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != exprTokenEOF {
+		return nil, errExpressionSyntaxAt(p.tok.column, fmt.Sprintf("unexpected token %q", p.tok.text))
+	}
+
+	return condition, nil
+}
+
+func lookupNamedCondition(name string, definitions *Definitions) (*Condition, bool) {
+	list, ok := definitions.Conditions[name]
+	if !ok || len(list) == 0 {
+		return nil, false
+	}
+	if len(list) == 1 {
+		return list[0], true
+	}
+	return &Condition{All: list}, true
+}
+
+type exprTokenKind int
+
+const (
+	exprTokenEOF exprTokenKind = iota
+	exprTokenNot
+	exprTokenAnd
+	exprTokenOr
+	exprTokenLParen
+	exprTokenRParen
+	exprTokenIdent
+)
+
+type exprToken struct {
+	kind   exprTokenKind
+	text   string
+	column int
+}
+
+// exprLexer tokenizes a condition expression, tracking 1-based column
+// offsets so the parser can report precise error locations.
+type exprLexer struct {
+	input string
+	pos   int
+}
+
+func newExprLexer(input string) *exprLexer {
+	return &exprLexer{input: input}
+}
+
+func (l *exprLexer) next() (exprToken, error) {
+	l.skipSpace()
+
+	column := l.pos + 1
+	if l.pos >= len(l.input) {
+		return exprToken{kind: exprTokenEOF, column: column}, nil
+	}
+
+	switch c := l.input[l.pos]; c {
+	case '!':
+		l.pos++
+		return exprToken{kind: exprTokenNot, text: "!", column: column}, nil
+	case '(':
+		l.pos++
+		return exprToken{kind: exprTokenLParen, text: "(", column: column}, nil
+	case ')':
+		l.pos++
+		return exprToken{kind: exprTokenRParen, text: ")", column: column}, nil
+	case '&':
+		if l.peekAt(1) == '&' {
+			l.pos += 2
+			return exprToken{kind: exprTokenAnd, text: "&&", column: column}, nil
+		}
+		return exprToken{}, errExpressionSyntaxAt(column, "expected '&&'")
+	case '|':
+		if l.peekAt(1) == '|' {
+			l.pos += 2
+			return exprToken{kind: exprTokenOr, text: "||", column: column}, nil
+		}
+		return exprToken{}, errExpressionSyntaxAt(column, "expected '||'")
+	default:
+		start := l.pos
+		for l.pos < len(l.input) && isIdentRune(rune(l.input[l.pos])) {
+			l.pos++
+		}
+		if l.pos == start {
+			return exprToken{}, errExpressionSyntaxAt(column, fmt.Sprintf("unexpected character %q", c))
+		}
+		return exprToken{kind: exprTokenIdent, text: l.input[start:l.pos], column: column}, nil
+	}
+}
+
+func (l *exprLexer) peekAt(offset int) byte {
+	if l.pos+offset >= len(l.input) {
+		return 0
+	}
+	return l.input[l.pos+offset]
+}
+
+func (l *exprLexer) skipSpace() {
+	for l.pos < len(l.input) && (l.input[l.pos] == ' ' || l.input[l.pos] == '\t') {
+		l.pos++
+	}
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-'
+}
+
+// exprParser is a one-token-lookahead recursive-descent parser over the
+// grammar documented on parseConditionExpression.
+type exprParser struct {
+	lexer       *exprLexer
+	tok         exprToken
+	definitions *Definitions
+}
+
+func (p *exprParser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *exprParser) parseExpr() (*Condition, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	var any []*Condition
+	for p.tok.kind == exprTokenOr {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		if any == nil {
+			any = []*Condition{left}
+		}
+		any = append(any, right)
+	}
+
+	if any != nil {
+		return &Condition{Any: any}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseTerm() (*Condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	var all []*Condition
+	for p.tok.kind == exprTokenAnd {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if all == nil {
+			all = []*Condition{left}
+		}
+		all = append(all, right)
+	}
+
+	if all != nil {
+		return &Condition{All: all}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (*Condition, error) {
+	if p.tok.kind == exprTokenNot {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Condition{Not: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (*Condition, error) {
+	switch p.tok.kind {
+	case exprTokenLParen:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		condition, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != exprTokenRParen {
+			return nil, errExpressionSyntaxAt(p.tok.column, "expected ')'")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return condition, nil
+	case exprTokenIdent:
+		name, column := p.tok.text, p.tok.column
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		condition, ok := lookupNamedCondition(name, p.definitions)
+		if !ok {
+			return nil, errExpressionSyntaxAt(column, fmt.Sprintf("unknown condition %q", name))
+		}
+		return condition, nil
+	case exprTokenEOF:
+		return nil, errExpressionSyntaxAt(p.tok.column, "unexpected end of expression")
+	default:
+		return nil, errExpressionSyntaxAt(p.tok.column, fmt.Sprintf("unexpected token %q", p.tok.text))
+	}
+}
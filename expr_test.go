@@ -0,0 +1,68 @@
+package msparser
+
+import "testing"
+
+func defsWithConditions(names ...string) *Definitions {
+	conditions := map[string][]*Condition{}
+	for _, name := range names {
+		conditions[name] = []*Condition{{Source: name, Checks: []*Check{{Name: "equals"}}}}
+	}
+	return &Definitions{Conditions: conditions}
+}
+
+func TestParseConditionExpressionPrecedence(t *testing.T) {
+	condition, err := parseConditionExpression("!a && (b || c)", defsWithConditions("a", "b", "c"))
+	if err != nil {
+		t.Fatalf("parseConditionExpression: %v", err)
+	}
+
+	if len(condition.All) != 2 {
+		t.Fatalf("condition.All = %+v, want 2 operands (!a and (b||c))", condition.All)
+	}
+	if condition.All[0].Not == nil || condition.All[0].Not.Source != "a" {
+		t.Fatalf("condition.All[0] = %+v, want Not{a}", condition.All[0])
+	}
+	if len(condition.All[1].Any) != 2 || condition.All[1].Any[0].Source != "b" || condition.All[1].Any[1].Source != "c" {
+		t.Fatalf("condition.All[1] = %+v, want Any{b, c}", condition.All[1])
+	}
+}
+
+func TestParseConditionExpressionUnknownIdent(t *testing.T) {
+	_, err := parseConditionExpression("missing", defsWithConditions())
+	if err == nil {
+		t.Fatal("expected an error for an undefined condition name")
+	}
+}
+
+func TestParseConditionExpressionSyntaxErrorColumn(t *testing.T) {
+	_, err := parseConditionExpression("a &", defsWithConditions("a"))
+	want := errExpressionSyntaxAt(3, "expected '&&'")
+	if err == nil || err.Error() != want.Error() {
+		t.Fatalf("err = %v, want %v", err, want)
+	}
+}
+
+func TestParseConditionExpressionUnclosedParen(t *testing.T) {
+	_, err := parseConditionExpression("(a", defsWithConditions("a"))
+	want := errExpressionSyntaxAt(3, "expected ')'")
+	if err == nil || err.Error() != want.Error() {
+		t.Fatalf("err = %v, want %v", err, want)
+	}
+}
+
+func TestParseConditionExpressionMultiItemNameWrapsInAll(t *testing.T) {
+	definitions := &Definitions{Conditions: map[string][]*Condition{
+		"both": {
+			{Source: "a", Checks: []*Check{{Name: "equals"}}},
+			{Source: "b", Checks: []*Check{{Name: "equals"}}},
+		},
+	}}
+
+	condition, err := parseConditionExpression("both", definitions)
+	if err != nil {
+		t.Fatalf("parseConditionExpression: %v", err)
+	}
+	if len(condition.All) != 2 {
+		t.Fatalf("condition = %+v, want the two-item list wrapped in All", condition)
+	}
+}
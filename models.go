@@ -40,23 +40,71 @@ type Step struct {
 // If [Filter.Target] is not empty, the filter will be set as the [Filter.Target] parameter.
 // If [Filter.Target] is empty, the filter will be applied to the [Filter.Source] parameter, but always
 // in parameters even if the original value was taken from query, headers, or body.
+//
+// [Filter.Source] and [Filter.Target] may be dot-chained paths (e.g. "body.user.address.city")
+// reaching into a nested value instead of a single top-level parameter; the parsed form is kept
+// alongside the original string in [Filter.SourceSelector] and [Filter.TargetSelector].
 type Filter struct {
-	Source string
-	Target string
-	Steps  []*Step
+	Source         string
+	SourceSelector *Selector
+	Target         string
+	TargetSelector *Selector
+	Steps          []*Step
 }
 
 // Condition is a set of [Check]s that must be satisfied for the [Condition] to be true.
 // If the [Condition] is true, the endpoint is matched.
 //
 // If [Condition.Any] or [Condition.All] is not empty, the condition will be true if any or all of their conditions are true.
+// If [Condition.Not] is set, the condition will be true if it is false.
+//
+// Only one of [Condition.Any], [Condition.All], [Condition.Not], or [Condition.Source] with [Condition.Checks] can be set.
+//
+// A condition can also be written as a boolean expression string (e.g.
+// "!auth.valid && (role.admin || role.editor)"), parsed into this same
+// tree by [parseConditionExpression]; IDENTs reference a named condition
+// under Definitions.Conditions.
 //
-// Only one of [Condition.Any], [Condition.All], or [Condition.Source] with [Condition.Checks] can be set.
+// Like [Filter.Source], [Condition.Source] may be a dot-chained path; the parsed form is kept
+// alongside the original string in [Condition.SourceSelector].
 type Condition struct {
-	Any    []*Condition
-	All    []*Condition
-	Source string
-	Checks []*Check
+	Any            []*Condition
+	All            []*Condition
+	Not            *Condition
+	Source         string
+	SourceSelector *Selector
+	Checks         []*Check
+}
+
+// SegmentKind identifies what a [Segment] addresses: a named object
+// field, a numeric array index, or a "*" wildcard over every element of
+// an array.
+type SegmentKind int
+
+const (
+	SegmentField SegmentKind = iota
+	SegmentIndex
+	SegmentWildcard
+)
+
+// Segment is one step of a [Selector] path, e.g. in "body.items.*.id"
+// the path is [{Field "items"} {Wildcard} {Field "id"}].
+type Segment struct {
+	Kind SegmentKind
+	// Name holds the field name when Kind is SegmentField.
+	Name string
+	// Index holds the array index when Kind is SegmentIndex.
+	Index int
+}
+
+// Selector is the parsed form of a dot-chained source/target string
+// such as "body.user.address.city", "query.page", or "body.items.0.id".
+// Root is the first segment (path, query, headers, or body); Path holds
+// the remaining segments so downstream evaluators can walk or iterate
+// over nested values without re-parsing the original string.
+type Selector struct {
+	Root string
+	Path []Segment
 }
 
 // Check is a single operation that must be satisfied for the [Condition] to be true.
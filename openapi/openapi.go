@@ -0,0 +1,115 @@
+// Package openapi imports OpenAPI 2.0 (Swagger) and OpenAPI 3.0 documents
+// and converts them into a [msparser.Spec].
+//
+// Each operation in the document becomes an [msparser.Endpoint]: the path
+// template and HTTP verb map directly onto Endpoint.Path and
+// Endpoint.Method, and the request body media type (from `consumes` in
+// v2 or `requestBody.content` in v3) is used to derive Endpoint.BodyFormat.
+// Required query and header parameters (from the path item's and the
+// operation's own "parameters", with "$ref" resolved against
+// components/parameters or v2's top-level "parameters") become
+// Conditions asserting their presence. When an operation defines more
+// than one response, the lowest 2xx response (or, if there is none, the
+// first response key in sorted order, in which "default" sorts after
+// numeric codes) becomes the endpoint's own Response and the remaining
+// responses become
+// sub-endpoints; each is selected by a Condition on whichever operation
+// parameter enumerates that status among its values, or, failing that,
+// a synthetic "response" query parameter carrying the status code (see
+// conditionForStatus).
+//
+// Per operationId, the parameter-presence Conditions and per-status
+// Responses generated above are also recorded under
+// Spec.Definitions.Conditions ("<operationId>_params") and
+// Spec.Definitions.Responses ("<operationId>_<status>"), sharing the
+// same objects referenced from the Endpoint. Every operation builds its
+// own objects; a later operation with the same operationId overwrites
+// these entries rather than reusing them.
+package openapi
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	msparser "github.com/mockspec/mockspec-go-parser"
+)
+
+var errInvalidDocument = fmt.Errorf("openapi: invalid document")
+var errUnsupportedVersion = fmt.Errorf("openapi: unsupported or missing version")
+
+func errInvalidDocumentReason(reason string) error {
+	return fmt.Errorf("%w: %s", errInvalidDocument, reason)
+}
+
+// ImportFile reads an OpenAPI document (YAML or JSON) from disk and
+// converts it into a Spec.
+func ImportFile(name string) (*msparser.Spec, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return Import(content)
+}
+
+// Import parses an OpenAPI 2.0 or 3.0 document, in YAML or JSON, and
+// converts it into a Spec. One Endpoint is generated per operation.
+func Import(input []byte) (*msparser.Spec, error) {
+	var rDoc any
+	if err := yaml.Unmarshal(input, &rDoc); err != nil {
+		return nil, err
+	}
+
+	rDocMap, ok := rDoc.(map[string]any)
+	if !ok {
+		return nil, errInvalidDocumentReason("document must be an object")
+	}
+
+	doc, err := newDocument(rDocMap)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &msparser.Spec{
+		Definitions: &msparser.Definitions{
+			Steps:      map[string][]*msparser.Step{},
+			Filters:    map[string][]*msparser.Filter{},
+			Conditions: map[string][]*msparser.Condition{},
+			Responses:  map[string]*msparser.Response{},
+		},
+		Endpoints: []*msparser.Endpoint{},
+	}
+
+	rPaths, ok := rDocMap["paths"].(map[string]any)
+	if !ok {
+		return nil, errInvalidDocumentReason("document must have a 'paths' object")
+	}
+
+	if err := fillEndpointsFromPaths(&spec.Endpoints, spec.Definitions, doc, rPaths); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// document carries the pieces of the raw OpenAPI document that the
+// converters need repeatedly: whether it is a v2 (Swagger) or v3
+// document, and the resolver used to follow intra-document $ref values.
+type document struct {
+	isV2     bool
+	resolver *resolver
+}
+
+func newDocument(rDoc map[string]any) (*document, error) {
+	if version, ok := rDoc["openapi"].(string); ok && len(version) > 0 && version[0] == '3' {
+		return &document{isV2: false, resolver: newResolver(rDoc, false)}, nil
+	}
+
+	if version, ok := rDoc["swagger"].(string); ok && version == "2.0" {
+		return &document{isV2: true, resolver: newResolver(rDoc, true)}, nil
+	}
+
+	return nil, errUnsupportedVersion
+}
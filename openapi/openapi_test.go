@@ -0,0 +1,200 @@
+package openapi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	msparser "github.com/mockspec/mockspec-go-parser"
+)
+
+func TestImportSetsResponseStatus(t *testing.T) {
+	spec, err := Import([]byte(`
+openapi: "3.0.0"
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+`))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	if got := spec.Endpoints[0].Response.Status; got != 200 {
+		t.Fatalf("Response.Status = %d, want 200", got)
+	}
+}
+
+func TestImportMultiResponseSubEndpoints(t *testing.T) {
+	spec, err := Import([]byte(`
+openapi: "3.0.0"
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+        "404":
+          description: not found
+`))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	endpoint := spec.Endpoints[0]
+	if endpoint.Response == nil || endpoint.Response.Status != 200 {
+		t.Fatalf("primary Response = %+v, want status 200", endpoint.Response)
+	}
+
+	if len(endpoint.Endpoints) != 1 {
+		t.Fatalf("len(Endpoints) = %d, want 1 sub-endpoint for the 404 response", len(endpoint.Endpoints))
+	}
+
+	sub := endpoint.Endpoints[0]
+	if sub.Response == nil || sub.Response.Status != 404 {
+		t.Fatalf("sub-endpoint Response = %+v, want status 404", sub.Response)
+	}
+	if len(sub.Conditions) != 1 || sub.Conditions[0].Source != "query.response" {
+		t.Fatalf("sub-endpoint Conditions = %+v, want a fallback query.response condition", sub.Conditions)
+	}
+
+	wantSelector, err := msparser.ParseSelector("query.response")
+	if err != nil {
+		t.Fatalf("ParseSelector: %v", err)
+	}
+	if got := sub.Conditions[0].SourceSelector; !reflect.DeepEqual(got, wantSelector) {
+		t.Fatalf("sub-endpoint Condition.SourceSelector = %+v, want %+v", got, wantSelector)
+	}
+}
+
+func TestImportSchemaRefCycleReturnsError(t *testing.T) {
+	_, err := Import([]byte(`
+openapi: "3.0.0"
+paths:
+  /nodes:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Node"
+components:
+  schemas:
+    Node:
+      type: object
+      properties:
+        child:
+          $ref: "#/components/schemas/Node"
+`))
+	if !errors.Is(err, errSchemaRefCycle) {
+		t.Fatalf("err = %v, want errSchemaRefCycle", err)
+	}
+}
+
+func TestImportResolvesSchemaRef(t *testing.T) {
+	spec, err := Import([]byte(`
+openapi: "3.0.0"
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+          example: fido
+`))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	body := spec.Endpoints[0].Response.Body
+	if body != `{"name":"fido"}` {
+		t.Fatalf("Response.Body = %q, want the faked Pet schema", body)
+	}
+}
+
+func TestImportV2Document(t *testing.T) {
+	spec, err := Import([]byte(`
+swagger: "2.0"
+paths:
+  /pets:
+    get:
+      consumes:
+        - application/json
+      responses:
+        "200":
+          description: ok
+          schema:
+            type: string
+`))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	endpoint := spec.Endpoints[0]
+	if endpoint.Method != "GET" || endpoint.Path != "/pets" {
+		t.Fatalf("Endpoint = %+v, want GET /pets", endpoint)
+	}
+	if endpoint.Response.Status != 200 {
+		t.Fatalf("Response.Status = %d, want 200", endpoint.Response.Status)
+	}
+	if endpoint.Response.Body != "string" {
+		t.Fatalf("Response.Body = %q, want the faked string schema", endpoint.Response.Body)
+	}
+}
+
+func TestImportRequiredParameterBecomesCondition(t *testing.T) {
+	spec, err := Import([]byte(`
+openapi: "3.0.0"
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - name: X-Api-Key
+          in: header
+          required: true
+      responses:
+        "200":
+          description: ok
+`))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	endpoint := spec.Endpoints[0]
+	if len(endpoint.Conditions) != 1 {
+		t.Fatalf("len(Conditions) = %d, want 1 for the required header parameter", len(endpoint.Conditions))
+	}
+	condition := endpoint.Conditions[0]
+	if condition.Source != "headers.X-Api-Key" {
+		t.Fatalf("Condition.Source = %q, want headers.X-Api-Key", condition.Source)
+	}
+
+	wantSelector, err := msparser.ParseSelector("headers.X-Api-Key")
+	if err != nil {
+		t.Fatalf("ParseSelector: %v", err)
+	}
+	if got := condition.SourceSelector; !reflect.DeepEqual(got, wantSelector) {
+		t.Fatalf("Condition.SourceSelector = %+v, want %+v", got, wantSelector)
+	}
+
+	defined := spec.Definitions.Conditions["listPets_params"]
+	if len(defined) != 1 || defined[0] != condition {
+		t.Fatalf("Definitions.Conditions[listPets_params] = %+v, want the same Condition referenced from the endpoint", defined)
+	}
+}
@@ -0,0 +1,136 @@
+package openapi
+
+import (
+	"fmt"
+
+	msparser "github.com/mockspec/mockspec-go-parser"
+)
+
+// parameter is a resolved "name"/"in"/"required"/"schema" entry from an
+// operation's (or its path item's) "parameters" array, after following
+// any "$ref".
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	schema   map[string]any
+}
+
+// operationParameters collects the parameters that apply to an
+// operation: the path item's shared "parameters" plus the operation's
+// own, the latter overriding a shared entry with the same name and
+// location, per the OpenAPI spec. Each entry's "$ref"
+// (components/parameters in v3, parameters in v2) is resolved via
+// doc.resolver.resolveParameter.
+func operationParameters(doc *document, rPathItem, rOperation map[string]any) ([]*parameter, error) {
+	byKey := map[string]*parameter{}
+	var order []string
+
+	collect := func(rParameters []any) error {
+		for _, rParam := range rParameters {
+			rParamMap, ok := rParam.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			if ref, ok := rParamMap["$ref"].(string); ok {
+				resolved, err := doc.resolver.resolveParameter(ref)
+				if err != nil {
+					return err
+				}
+				rParamMap = resolved
+			}
+
+			name, _ := rParamMap["name"].(string)
+			in, _ := rParamMap["in"].(string)
+			if name == "" || in == "" {
+				continue
+			}
+
+			required, _ := rParamMap["required"].(bool)
+			schema, _ := rParamMap["schema"].(map[string]any)
+			if schema == nil && doc.isV2 {
+				// v2 parameters describe their type/enum inline instead
+				// of nesting a "schema" object.
+				schema = rParamMap
+			}
+
+			key := in + ":" + name
+			if _, seen := byKey[key]; !seen {
+				order = append(order, key)
+			}
+			byKey[key] = &parameter{name: name, in: in, required: required, schema: schema}
+		}
+		return nil
+	}
+
+	if rParameters, ok := rPathItem["parameters"].([]any); ok {
+		if err := collect(rParameters); err != nil {
+			return nil, err
+		}
+	}
+	if rParameters, ok := rOperation["parameters"].([]any); ok {
+		if err := collect(rParameters); err != nil {
+			return nil, err
+		}
+	}
+
+	params := make([]*parameter, 0, len(order))
+	for _, key := range order {
+		params = append(params, byKey[key])
+	}
+	return params, nil
+}
+
+// selectorRootForLocation maps an OpenAPI parameter "in" value onto the
+// [msparser.Selector] root it corresponds to. Path parameters are
+// always present by construction and cookie parameters have no
+// equivalent root, so neither is selectable.
+func selectorRootForLocation(in string) (string, bool) {
+	switch in {
+	case "query":
+		return "query", true
+	case "header":
+		return "headers", true
+	default:
+		return "", false
+	}
+}
+
+// conditionsForParameters builds one Condition per required query or
+// header parameter, asserting that it is present on the incoming
+// request.
+func conditionsForParameters(params []*parameter) ([]*msparser.Condition, error) {
+	var conditions []*msparser.Condition
+	for _, param := range params {
+		if !param.required {
+			continue
+		}
+		root, ok := selectorRootForLocation(param.in)
+		if !ok {
+			continue
+		}
+		condition, err := conditionForSource(fmt.Sprintf("%s.%s", root, param.name), []*msparser.Check{{Name: "present"}})
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// conditionForSource builds a Condition with Source set to source and
+// SourceSelector set to its parsed form via [msparser.ParseSelector],
+// matching the invariant the parser itself maintains for every
+// Condition/Filter with a Source.
+func conditionForSource(source string, checks []*msparser.Check) (*msparser.Condition, error) {
+	selector, err := msparser.ParseSelector(source)
+	if err != nil {
+		return nil, err
+	}
+	return &msparser.Condition{
+		Source:         source,
+		SourceSelector: selector,
+		Checks:         checks,
+	}, nil
+}
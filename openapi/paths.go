@@ -0,0 +1,341 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	msparser "github.com/mockspec/mockspec-go-parser"
+)
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func fillEndpointsFromPaths(endpoints *[]*msparser.Endpoint, definitions *msparser.Definitions, doc *document, rPaths map[string]any) error {
+	paths := sortedKeys(rPaths)
+
+	for _, path := range paths {
+		rPathItem, ok := rPaths[path].(map[string]any)
+		if !ok {
+			return errInvalidDocumentReason("path item must be an object: " + path)
+		}
+
+		for _, method := range httpMethods {
+			rOperation, ok := rPathItem[method].(map[string]any)
+			if !ok {
+				continue
+			}
+
+			endpoint, err := createEndpointFromOperation(definitions, doc, path, method, rPathItem, rOperation)
+			if err != nil {
+				return err
+			}
+
+			*endpoints = append(*endpoints, endpoint)
+		}
+	}
+
+	return nil
+}
+
+func createEndpointFromOperation(definitions *msparser.Definitions, doc *document, path, method string, rPathItem, rOperation map[string]any) (*msparser.Endpoint, error) {
+	endpoint := &msparser.Endpoint{
+		Method:     strings.ToUpper(method),
+		Path:       path,
+		BodyFormat: requestBodyFormat(doc, rOperation),
+	}
+
+	if description, ok := rOperation["summary"].(string); ok {
+		endpoint.Description = description
+	} else if description, ok := rOperation["description"].(string); ok {
+		endpoint.Description = description
+	}
+
+	params, err := operationParameters(doc, rPathItem, rOperation)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := rOperation["operationId"].(string)
+
+	conditions, err := conditionsForParameters(params)
+	if err != nil {
+		return nil, err
+	}
+	if len(conditions) > 0 {
+		if name != "" {
+			definitions.Conditions[name+"_params"] = conditions
+		}
+		endpoint.Conditions = conditions
+	}
+
+	rResponses, ok := rOperation["responses"].(map[string]any)
+	if !ok || len(rResponses) == 0 {
+		return nil, errInvalidDocumentReason("operation must have at least one response: " + method + " " + path)
+	}
+
+	statuses := sortedKeys(rResponses)
+	primary := primaryStatus(statuses)
+
+	for _, status := range statuses {
+		rResponse, ok := rResponses[status].(map[string]any)
+		if !ok {
+			return nil, errInvalidDocumentReason("response must be an object: " + status)
+		}
+
+		response, err := createResponseFromOperationResponse(doc, status, rResponse)
+		if err != nil {
+			return nil, err
+		}
+
+		if name != "" {
+			definitions.Responses[name+"_"+status] = response
+		}
+
+		if status == primary {
+			endpoint.Response = response
+			continue
+		}
+
+		subCondition, err := conditionForStatus(status, params)
+		if err != nil {
+			return nil, err
+		}
+
+		endpoint.Endpoints = append(endpoint.Endpoints, &msparser.Endpoint{
+			Description: "status " + status,
+			Conditions:  []*msparser.Condition{subCondition},
+			Response:    response,
+		})
+	}
+
+	return endpoint, nil
+}
+
+// primaryStatus picks the response that becomes the endpoint's own
+// Response rather than a conditional sub-endpoint: the lowest 2xx code
+// if one is present, otherwise the first response in sorted order.
+func primaryStatus(statuses []string) string {
+	for _, status := range statuses {
+		if len(status) == 3 && status[0] == '2' {
+			return status
+		}
+	}
+	return statuses[0]
+}
+
+// conditionForStatus builds the Condition used to route to a
+// non-primary response. If the operation defines a query or header
+// parameter whose schema enumerates this status among its values (a
+// common pattern for APIs that let a caller pick a canned response),
+// the condition matches on that real parameter. Otherwise, since
+// OpenAPI itself does not describe which request triggers which
+// response, this is a deliberate fallback: it routes on a synthetic
+// "response" query parameter carrying the desired status code, which
+// does not exist in the source document.
+func conditionForStatus(status string, params []*parameter) (*msparser.Condition, error) {
+	for _, param := range params {
+		root, ok := selectorRootForLocation(param.in)
+		if !ok || param.schema == nil {
+			continue
+		}
+		enum, _ := param.schema["enum"].([]any)
+		for _, value := range enum {
+			if stringify(value) != status {
+				continue
+			}
+			return conditionForSource(fmt.Sprintf("%s.%s", root, param.name), []*msparser.Check{
+				{
+					Name:       "equals",
+					Parameters: map[string]any{"value": status},
+				},
+			})
+		}
+	}
+
+	return conditionForSource("query.response", []*msparser.Check{
+		{
+			Name:       "equals",
+			Parameters: map[string]any{"value": status},
+		},
+	})
+}
+
+// createResponseFromOperationResponse converts one entry of an
+// operation's "responses" object into a Response. status is the map key
+// the response was found under (e.g. "200", "404", or "default") and is
+// parsed into Response.Status; non-numeric keys such as "default" leave
+// it at zero.
+func createResponseFromOperationResponse(doc *document, status string, rResponse map[string]any) (*msparser.Response, error) {
+	if ref, ok := rResponse["$ref"].(string); ok {
+		resolved, err := doc.resolver.resolveResponse(ref)
+		if err != nil {
+			return nil, err
+		}
+		rResponse = resolved
+	}
+
+	statusCode, _ := strconv.Atoi(status)
+	response := &msparser.Response{Status: statusCode}
+
+	mediaType, rMediaType := primaryResponseMediaType(doc, rResponse)
+	response.Format = formatFromMediaType(mediaType)
+
+	body, err := responseBody(doc, rMediaType)
+	if err != nil {
+		return nil, err
+	}
+	response.Body = body
+
+	if headers := responseHeaders(doc, rResponse); len(headers) > 0 {
+		response.Headers = headers
+	}
+
+	return response, nil
+}
+
+// primaryResponseMediaType returns the first media type entry for a
+// response, preferring "content" (v3) and falling back to "examples"
+// (v2, keyed directly by media type).
+func primaryResponseMediaType(doc *document, rResponse map[string]any) (string, map[string]any) {
+	if rContent, ok := rResponse["content"].(map[string]any); ok {
+		for _, mediaType := range sortedKeys(rContent) {
+			if rMediaType, ok := rContent[mediaType].(map[string]any); ok {
+				return mediaType, rMediaType
+			}
+		}
+	}
+
+	if rExamples, ok := rResponse["examples"].(map[string]any); ok {
+		for _, mediaType := range sortedKeys(rExamples) {
+			return mediaType, map[string]any{"example": rExamples[mediaType]}
+		}
+	}
+
+	if rSchema, ok := rResponse["schema"].(map[string]any); ok {
+		return "application/json", map[string]any{"schema": rSchema}
+	}
+
+	return "", nil
+}
+
+func responseBody(doc *document, rMediaType map[string]any) (string, error) {
+	if rMediaType == nil {
+		return "", nil
+	}
+
+	if example, ok := rMediaType["example"]; ok {
+		return marshalBody(example)
+	}
+
+	if rExamples, ok := rMediaType["examples"].(map[string]any); ok {
+		for _, name := range sortedKeys(rExamples) {
+			rExample, ok := rExamples[name].(map[string]any)
+			if !ok {
+				continue
+			}
+			if value, ok := rExample["value"]; ok {
+				return marshalBody(value)
+			}
+		}
+	}
+
+	if rSchema, ok := rMediaType["schema"].(map[string]any); ok {
+		fake, err := fakeFromSchema(doc, rSchema)
+		if err != nil {
+			return "", err
+		}
+		return marshalBody(fake)
+	}
+
+	return "", nil
+}
+
+func marshalBody(value any) (string, error) {
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func responseHeaders(doc *document, rResponse map[string]any) map[string][]string {
+	rHeaders, ok := rResponse["headers"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	headers := map[string][]string{}
+	for name, rHeader := range rHeaders {
+		rHeaderMap, ok := rHeader.(map[string]any)
+		if !ok {
+			continue
+		}
+		if example, ok := rHeaderMap["example"]; ok {
+			headers[name] = []string{stringify(example)}
+			continue
+		}
+		if rSchema, ok := rHeaderMap["schema"].(map[string]any); ok {
+			if fake, err := fakeFromSchema(doc, rSchema); err == nil && fake != nil {
+				headers[name] = []string{stringify(fake)}
+			}
+		}
+	}
+
+	return headers
+}
+
+func requestBodyFormat(doc *document, rOperation map[string]any) string {
+	if rRequestBody, ok := rOperation["requestBody"].(map[string]any); ok {
+		if rContent, ok := rRequestBody["content"].(map[string]any); ok {
+			for _, mediaType := range sortedKeys(rContent) {
+				return formatFromMediaType(mediaType)
+			}
+		}
+	}
+
+	if rConsumes, ok := rOperation["consumes"].([]any); ok && len(rConsumes) > 0 {
+		if mediaType, ok := rConsumes[0].(string); ok {
+			return formatFromMediaType(mediaType)
+		}
+	}
+
+	return ""
+}
+
+func formatFromMediaType(mediaType string) string {
+	switch {
+	case mediaType == "":
+		return ""
+	case strings.Contains(mediaType, "json"):
+		return "json"
+	case strings.Contains(mediaType, "xml"):
+		return "xml"
+	default:
+		return "raw"
+	}
+}
+
+func stringify(value any) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,63 @@
+package openapi
+
+import "strings"
+
+// resolver resolves intra-document "$ref" values such as
+// "#/components/schemas/Pet" (OpenAPI 3) or "#/definitions/Pet"
+// (Swagger 2) against the raw, already-unmarshalled document.
+type resolver struct {
+	root map[string]any
+	isV2 bool
+}
+
+func newResolver(root map[string]any, isV2 bool) *resolver {
+	return &resolver{root: root, isV2: isV2}
+}
+
+// resolveSchema follows a "$ref" pointing at a schema definition
+// (components/schemas in v3, definitions in v2) and returns the
+// referenced schema object.
+func (r *resolver) resolveSchema(ref string) (map[string]any, error) {
+	return r.resolve(ref)
+}
+
+// resolveParameter follows a "$ref" pointing at a reusable parameter
+// (components/parameters in v3, parameters in v2).
+func (r *resolver) resolveParameter(ref string) (map[string]any, error) {
+	return r.resolve(ref)
+}
+
+// resolveResponse follows a "$ref" pointing at a reusable response
+// (components/responses in v3, responses in v2).
+func (r *resolver) resolveResponse(ref string) (map[string]any, error) {
+	return r.resolve(ref)
+}
+
+// resolve follows any "#/a/b/c" JSON pointer against the root document,
+// regardless of which section it targets.
+func (r *resolver) resolve(ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, errInvalidDocumentReason("only intra-document refs are supported: " + ref)
+	}
+
+	segments := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+
+	var current any = r.root
+	for _, segment := range segments {
+		currentMap, ok := current.(map[string]any)
+		if !ok {
+			return nil, errInvalidDocumentReason("unresolvable $ref: " + ref)
+		}
+		current, ok = currentMap[segment]
+		if !ok {
+			return nil, errInvalidDocumentReason("unresolvable $ref: " + ref)
+		}
+	}
+
+	currentMap, ok := current.(map[string]any)
+	if !ok {
+		return nil, errInvalidDocumentReason("$ref does not point at an object: " + ref)
+	}
+
+	return currentMap, nil
+}
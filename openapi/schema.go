@@ -0,0 +1,89 @@
+package openapi
+
+import "fmt"
+
+var errSchemaRefCycle = fmt.Errorf("openapi: cyclic schema $ref")
+
+// fakeFromSchema produces a representative JSON-able value for a schema
+// object. It favours, in order: an explicit "example", the first entry
+// of "enum", and otherwise a zero-ish value for the schema's "type".
+// $ref schemas are resolved first. This is intentionally not a full
+// JSON Schema implementation — it only covers enough to seed believable
+// mock responses.
+func fakeFromSchema(doc *document, rSchema map[string]any) (any, error) {
+	return fakeFromSchemaVisiting(doc, rSchema, map[string]bool{})
+}
+
+// fakeFromSchemaVisiting is fakeFromSchema with the "$ref" values
+// currently being resolved in this call chain, so a self- or
+// mutually-referential schema (e.g. a recursive "Node" object whose
+// "child" property $refs back to "Node") reports errSchemaRefCycle
+// instead of recursing forever.
+func fakeFromSchemaVisiting(doc *document, rSchema map[string]any, visiting map[string]bool) (any, error) {
+	if ref, ok := rSchema["$ref"].(string); ok {
+		if visiting[ref] {
+			return nil, fmt.Errorf("%w: %s", errSchemaRefCycle, ref)
+		}
+		visiting[ref] = true
+
+		resolved, err := doc.resolver.resolveSchema(ref)
+		if err != nil {
+			return nil, err
+		}
+		value, err := fakeFromSchemaVisiting(doc, resolved, visiting)
+
+		delete(visiting, ref)
+		return value, err
+	}
+
+	if example, ok := rSchema["example"]; ok {
+		return example, nil
+	}
+
+	if enum, ok := rSchema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0], nil
+	}
+
+	schemaType, _ := rSchema["type"].(string)
+
+	switch schemaType {
+	case "object", "":
+		rProperties, ok := rSchema["properties"].(map[string]any)
+		if !ok {
+			return map[string]any{}, nil
+		}
+		fake := map[string]any{}
+		for name, rProperty := range rProperties {
+			rPropertyMap, ok := rProperty.(map[string]any)
+			if !ok {
+				continue
+			}
+			value, err := fakeFromSchemaVisiting(doc, rPropertyMap, visiting)
+			if err != nil {
+				return nil, err
+			}
+			fake[name] = value
+		}
+		return fake, nil
+	case "array":
+		rItems, ok := rSchema["items"].(map[string]any)
+		if !ok {
+			return []any{}, nil
+		}
+		item, err := fakeFromSchemaVisiting(doc, rItems, visiting)
+		if err != nil {
+			return nil, err
+		}
+		return []any{item}, nil
+	case "string":
+		return "string", nil
+	case "integer":
+		return 0, nil
+	case "number":
+		return 0.0, nil
+	case "boolean":
+		return false, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,171 @@
+package msparser
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var errRefCycle = fmt.Errorf("parse: cyclic $ref")
+var errRemoteNotAllowed = fmt.Errorf("parse: remote $ref not allowed (set ParseOptions.AllowRemote)")
+
+// ParseOptions configures how ParseWithOptions and
+// ParseFromFileWithOptions resolve "$ref" values that point outside the
+// document being parsed.
+//
+// With the zero value, file $ref URIs are read from disk relative to the
+// current working directory, and http(s):// $ref URIs are rejected.
+type ParseOptions struct {
+	// BaseDir is the directory relative file $ref URIs are resolved
+	// against. When parsing from a file, ParseFromFileWithOptions
+	// defaults this to the directory of that file.
+	BaseDir string
+
+	// Loader, when set, is used to fetch the bytes for an external
+	// $ref URI instead of the default behavior (reading from disk, or
+	// issuing an HTTP GET when AllowRemote is set and the URI has an
+	// http(s) scheme). The uri passed in is the canonicalized form:
+	// an absolute file path, or an untouched http(s) URL.
+	Loader func(uri string) ([]byte, error)
+
+	// AllowRemote gates resolution of http(s):// $ref URIs. It has no
+	// effect on file paths.
+	AllowRemote bool
+
+	// Strict rejects documents that contain a key the parser does not
+	// recognize (e.g. a typo'd field name) instead of silently ignoring it.
+	Strict bool
+}
+
+// ParseWithOptions parses the input and returns a Spec object.
+// The input must be a valid YAML content.
+// The function resolves any references to definitions, including
+// external ones if opts allows it.
+func ParseWithOptions(input []byte, opts ParseOptions) (*Spec, error) {
+	resolver := newRefResolver(opts)
+	return parse(input, opts.Strict, resolver)
+}
+
+// ParseFromFileWithOptions reads the file and returns a Spec object,
+// resolving external $ref URIs relative to the file's directory unless
+// opts.BaseDir is already set.
+func ParseFromFileWithOptions(name string, opts ParseOptions) (*Spec, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.BaseDir == "" {
+		opts.BaseDir = filepath.Dir(name)
+	}
+
+	return ParseWithOptions(content, opts)
+}
+
+// refResolver loads and caches the documents targeted by external $ref
+// URIs for a single top-level Parse call, detecting cycles between
+// mutually-referencing files.
+type refResolver struct {
+	opts ParseOptions
+
+	// visiting holds canonical URIs currently being resolved, so a
+	// file that (directly or transitively) references itself fails
+	// with errRefCycle instead of recursing forever.
+	visiting map[string]bool
+
+	// resolved caches the Spec for each canonical URI so the same
+	// external document is only read and parsed once per Parse call.
+	resolved map[string]*Spec
+}
+
+func newRefResolver(opts ParseOptions) *refResolver {
+	return &refResolver{
+		opts:     opts,
+		visiting: map[string]bool{},
+		resolved: map[string]*Spec{},
+	}
+}
+
+// resolve returns the Spec produced by parsing the document at uri,
+// relative to the resolver's current base directory.
+func (r *refResolver) resolve(uri string) (*Spec, error) {
+	canonical, remote, err := r.canonicalize(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec, ok := r.resolved[canonical]; ok {
+		return spec, nil
+	}
+	if r.visiting[canonical] {
+		return nil, fmt.Errorf("%w: %s", errRefCycle, canonical)
+	}
+	r.visiting[canonical] = true
+
+	content, err := r.load(canonical, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	subOpts := r.opts
+	if !remote {
+		subOpts.BaseDir = filepath.Dir(canonical)
+	}
+	subResolver := &refResolver{opts: subOpts, visiting: r.visiting, resolved: r.resolved}
+
+	spec, err := parse(content, subOpts.Strict, subResolver)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(r.visiting, canonical)
+	r.resolved[canonical] = spec
+
+	return spec, nil
+}
+
+func (r *refResolver) canonicalize(uri string) (canonical string, remote bool, err error) {
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		if !r.opts.AllowRemote {
+			return "", false, errRemoteNotAllowed
+		}
+		return uri, true, nil
+	}
+
+	path := uri
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.opts.BaseDir, path)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	return absPath, false, nil
+}
+
+func (r *refResolver) load(canonical string, remote bool) ([]byte, error) {
+	if r.opts.Loader != nil {
+		return r.opts.Loader(canonical)
+	}
+
+	if remote {
+		resp, err := http.Get(canonical)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("parse: fetching %s: unexpected status %s", canonical, resp.Status)
+		}
+
+		return io.ReadAll(resp.Body)
+	}
+
+	return os.ReadFile(canonical)
+}
@@ -0,0 +1,85 @@
+package msparser
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExternalRefResolutionAndCaching(t *testing.T) {
+	loads := map[string]int{}
+	opts := ParseOptions{
+		BaseDir: "/virtual",
+		Loader: func(uri string) ([]byte, error) {
+			loads[uri]++
+			if uri == "/virtual/shared.yaml" {
+				return []byte("definitions:\n  responses:\n    ok:\n      status: 200\n"), nil
+			}
+			return nil, fmt.Errorf("unknown uri: %s", uri)
+		},
+	}
+
+	spec, err := ParseWithOptions([]byte(`
+endpoints:
+  - path: /a
+    method: GET
+    response:
+      $ref: "shared.yaml#/definitions/responses/ok"
+  - path: /b
+    method: GET
+    response:
+      $ref: "shared.yaml#/definitions/responses/ok"
+`), opts)
+	if err != nil {
+		t.Fatalf("ParseWithOptions: %v", err)
+	}
+
+	if got := spec.Endpoints[0].Response.Status; got != 200 {
+		t.Fatalf("Response.Status = %d, want 200", got)
+	}
+	if got := spec.Endpoints[1].Response.Status; got != 200 {
+		t.Fatalf("Response.Status = %d, want 200", got)
+	}
+	if loads["/virtual/shared.yaml"] != 1 {
+		t.Fatalf("shared.yaml was loaded %d times via two $refs, want exactly 1 (resolver should cache per Parse call)", loads["/virtual/shared.yaml"])
+	}
+}
+
+func TestExternalRefCycleIsDetected(t *testing.T) {
+	opts := ParseOptions{
+		BaseDir: "/virtual",
+		Loader: func(uri string) ([]byte, error) {
+			switch uri {
+			case "/virtual/a.yaml":
+				return []byte("definitions:\n  responses:\n    a:\n      $ref: \"b.yaml#/definitions/responses/b\"\n"), nil
+			case "/virtual/b.yaml":
+				return []byte("definitions:\n  responses:\n    b:\n      $ref: \"a.yaml#/definitions/responses/a\"\n"), nil
+			}
+			return nil, fmt.Errorf("unknown uri: %s", uri)
+		},
+	}
+
+	_, err := ParseWithOptions([]byte(`
+endpoints:
+  - path: /a
+    method: GET
+    response:
+      $ref: "a.yaml#/definitions/responses/a"
+`), opts)
+	if !errors.Is(err, errRefCycle) {
+		t.Fatalf("err = %v, want errRefCycle", err)
+	}
+}
+
+func TestExternalRefRemoteRequiresAllowRemote(t *testing.T) {
+	_, err := ParseWithOptions([]byte(`
+endpoints:
+  - path: /a
+    method: GET
+    response:
+      $ref: "http://example.com/shared.yaml#/definitions/responses/ok"
+`), ParseOptions{})
+	if !errors.Is(err, errRemoteNotAllowed) {
+		t.Fatalf("err = %v, want errRemoteNotAllowed", err)
+	}
+}
@@ -2,8 +2,9 @@ package msparser
 
 import (
 	"fmt"
+	"strings"
+
 	"gopkg.in/yaml.v3"
-	"os"
 )
 
 var errInvalidInput = fmt.Errorf("parse: invalid input")
@@ -20,18 +21,20 @@ func errUnknownDefinitionWithName(group, ref string) error {
 
 // ParseFromFile reads the file and returns a Spec object.
 func ParseFromFile(name string) (*Spec, error) {
-	content, err := os.ReadFile(name)
-	if err != nil {
-		return nil, err
-	}
-
-	return Parse(content)
+	return ParseFromFileWithOptions(name, ParseOptions{})
 }
 
 // Parse parses the input and returns a Spec object.
 // The input must be a valid YAML content.
 // The function resolves any references to definitions.
 func Parse(input []byte) (*Spec, error) {
+	return ParseWithOptions(input, ParseOptions{})
+}
+
+// parse is the shared implementation behind Parse and ParseWithOptions.
+// resolver carries the options and state (visited/cached external
+// documents) used to resolve "$ref" values that escape this document.
+func parse(input []byte, strict bool, resolver *refResolver) (*Spec, error) {
 	var rSpec any
 	if err := yaml.Unmarshal(input, &rSpec); err != nil {
 		return nil, err
@@ -43,14 +46,25 @@ func Parse(input []byte) (*Spec, error) {
 	}
 
 	spec := createEmpty()
+	ctx := &parseCtx{definitions: spec.Definitions, resolver: resolver, strict: strict}
 
-	if err := fillSpec(rSpecMap, spec); err != nil {
+	if err := fillSpec(rSpecMap, spec, ctx); err != nil {
 		return nil, err
 	}
 
 	return spec, nil
 }
 
+// parseCtx carries the state needed while walking a single document:
+// the Definitions being populated, the resolver used to follow "$ref"
+// values that point at another document, and whether unknown fields
+// should be rejected.
+type parseCtx struct {
+	definitions *Definitions
+	resolver    *refResolver
+	strict      bool
+}
+
 func createEmpty() *Spec {
 	return &Spec{
 		Definitions: &Definitions{
@@ -63,23 +77,33 @@ func createEmpty() *Spec {
 	}
 }
 
-func fillSpec(rSpec map[string]any, spec *Spec) error {
-	rSpecDefinitions, err := extractStringMap(rSpec, "definitions")
-	if err != nil {
+// specShape mirrors the top-level keys of a document: "definitions" and
+// "endpoints" are left as any/[]any because their contents need
+// ctx-aware handling (name lookups, $ref resolution) that a plain
+// decode into Spec can't do on its own.
+type specShape struct {
+	Definitions any
+	Endpoints   []any
+}
+
+func fillSpec(rSpec map[string]any, spec *Spec, ctx *parseCtx) error {
+	var shape specShape
+	if err := decode(rSpec, &shape, ctx.strict); err != nil {
 		return err
 	}
-	if rSpecDefinitions != nil {
-		if err := fillDefinitions(rSpecDefinitions, spec.Definitions); err != nil {
+
+	if shape.Definitions != nil {
+		rSpecDefinitions, ok := shape.Definitions.(map[string]any)
+		if !ok {
+			return errInvalidInputReason("expected 'definitions' to be an object")
+		}
+		if err := fillDefinitions(rSpecDefinitions, ctx); err != nil {
 			return err
 		}
 	}
 
-	rSpecEndpoints, err := extractSliceOfAny(rSpec, "endpoints")
-	if err != nil {
-		return err
-	}
-	if rSpecEndpoints != nil {
-		if err := fillEndpoints(&spec.Endpoints, rSpecEndpoints, spec.Definitions); err != nil {
+	if shape.Endpoints != nil {
+		if err := fillEndpoints(&spec.Endpoints, shape.Endpoints, ctx); err != nil {
 			return err
 		}
 	}
@@ -87,45 +111,55 @@ func fillSpec(rSpec map[string]any, spec *Spec) error {
 	return nil
 }
 
-func fillDefinitions(rDefinitions map[string]any, definitions *Definitions) error {
-	var err error
+type definitionsShape struct {
+	Steps      any
+	Filters    any
+	Conditions any
+	Responses  any
+}
 
-	rSteps, err := extractStringMap(rDefinitions, "steps")
-	if err != nil {
+func fillDefinitions(rDefinitions map[string]any, ctx *parseCtx) error {
+	var shape definitionsShape
+	if err := decode(rDefinitions, &shape, ctx.strict); err != nil {
 		return err
 	}
-	if rSteps != nil {
-		if err = fillStepsMap(&definitions.Steps, rSteps, definitions); err != nil {
+
+	if shape.Steps != nil {
+		rSteps, ok := shape.Steps.(map[string]any)
+		if !ok {
+			return errInvalidInputReason("expected 'steps' to be an object")
+		}
+		if err := fillStepsMap(&ctx.definitions.Steps, rSteps, ctx); err != nil {
 			return err
 		}
 	}
 
-	rFilters, err := extractStringMap(rDefinitions, "filters")
-	if err != nil {
-		return err
-	}
-	if rFilters != nil {
-		if err = fillFiltersMap(&definitions.Filters, rFilters, definitions); err != nil {
+	if shape.Filters != nil {
+		rFilters, ok := shape.Filters.(map[string]any)
+		if !ok {
+			return errInvalidInputReason("expected 'filters' to be an object")
+		}
+		if err := fillFiltersMap(&ctx.definitions.Filters, rFilters, ctx); err != nil {
 			return err
 		}
 	}
 
-	rConditions, err := extractStringMap(rDefinitions, "conditions")
-	if err != nil {
-		return err
-	}
-	if rConditions != nil {
-		if err = fillConditionsMap(&definitions.Conditions, rConditions, definitions); err != nil {
+	if shape.Conditions != nil {
+		rConditions, ok := shape.Conditions.(map[string]any)
+		if !ok {
+			return errInvalidInputReason("expected 'conditions' to be an object")
+		}
+		if err := fillConditionsMap(&ctx.definitions.Conditions, rConditions, ctx); err != nil {
 			return err
 		}
 	}
 
-	rResponses, err := extractStringMap(rDefinitions, "responses")
-	if err != nil {
-		return err
-	}
-	if rResponses != nil {
-		if err = fillResponsesMap(&definitions.Responses, rResponses, definitions); err != nil {
+	if shape.Responses != nil {
+		rResponses, ok := shape.Responses.(map[string]any)
+		if !ok {
+			return errInvalidInputReason("expected 'responses' to be an object")
+		}
+		if err := fillResponsesMap(&ctx.definitions.Responses, rResponses, ctx); err != nil {
 			return err
 		}
 	}
@@ -133,14 +167,14 @@ func fillDefinitions(rDefinitions map[string]any, definitions *Definitions) erro
 	return nil
 }
 
-func fillStepsMap(stepsMap *map[string][]*Step, rSteps map[string]any, definitions *Definitions) error {
+func fillStepsMap(stepsMap *map[string][]*Step, rSteps map[string]any, ctx *parseCtx) error {
 	for stepName, rStepItems := range rSteps {
 		rStepItemsSlice, ok := rStepItems.([]any)
 		if !ok {
 			return errInvalidInputReason(fmt.Sprintf("each step must be an array if step items (%s is not)", stepName))
 		}
 		var stepsList []*Step
-		if err := fillStepsItems(&stepsList, rStepItemsSlice, definitions); err != nil {
+		if err := fillStepsItems(&stepsList, rStepItemsSlice, ctx); err != nil {
 			return err
 		}
 		(*stepsMap)[stepName] = stepsList
@@ -149,7 +183,7 @@ func fillStepsMap(stepsMap *map[string][]*Step, rSteps map[string]any, definitio
 	return nil
 }
 
-func fillStepsItems(steps *[]*Step, rStepItems []any, definitions *Definitions) error {
+func fillStepsItems(steps *[]*Step, rStepItems []any, ctx *parseCtx) error {
 	if len(rStepItems) == 0 {
 		return errInvalidInputReason("each step must have at least one item")
 	}
@@ -159,7 +193,7 @@ func fillStepsItems(steps *[]*Step, rStepItems []any, definitions *Definitions)
 		if !ok {
 			return errInvalidInputReason("each step item must be an object")
 		}
-		if err := fillStepsItem(steps, rStepItemMap, definitions); err != nil {
+		if err := fillStepsItem(steps, rStepItemMap, ctx); err != nil {
 			return err
 		}
 	}
@@ -167,8 +201,8 @@ func fillStepsItems(steps *[]*Step, rStepItems []any, definitions *Definitions)
 	return nil
 }
 
-func fillStepsItem(steps *[]*Step, rStepItem map[string]any, definitions *Definitions) error {
-	newSteps, err := createSteps(rStepItem, definitions)
+func fillStepsItem(steps *[]*Step, rStepItem map[string]any, ctx *parseCtx) error {
+	newSteps, err := createSteps(rStepItem, ctx)
 	if err != nil {
 		return err
 	}
@@ -178,14 +212,14 @@ func fillStepsItem(steps *[]*Step, rStepItem map[string]any, definitions *Defini
 	return nil
 }
 
-func fillFiltersMap(filtersMap *map[string][]*Filter, rFilters map[string]any, definitions *Definitions) error {
+func fillFiltersMap(filtersMap *map[string][]*Filter, rFilters map[string]any, ctx *parseCtx) error {
 	for filterName, rFilterItems := range rFilters {
 		rFilterItemsSlice, ok := rFilterItems.([]any)
 		if !ok {
 			return errInvalidInputReason(fmt.Sprintf("each filter must be an array of filter items (%s is not)", filterName))
 		}
 		var filtersList []*Filter
-		if err := fillFiltersItems(&filtersList, rFilterItemsSlice, definitions); err != nil {
+		if err := fillFiltersItems(&filtersList, rFilterItemsSlice, ctx); err != nil {
 			return err
 		}
 		(*filtersMap)[filterName] = filtersList
@@ -194,7 +228,7 @@ func fillFiltersMap(filtersMap *map[string][]*Filter, rFilters map[string]any, d
 	return nil
 }
 
-func fillFiltersItems(filters *[]*Filter, rFilterItems []any, definitions *Definitions) error {
+func fillFiltersItems(filters *[]*Filter, rFilterItems []any, ctx *parseCtx) error {
 	if len(rFilterItems) == 0 {
 		return errInvalidInputReason("each filter must have at least one item")
 	}
@@ -204,7 +238,7 @@ func fillFiltersItems(filters *[]*Filter, rFilterItems []any, definitions *Defin
 		if !ok {
 			return errInvalidInputReason("each filter item must be an object")
 		}
-		if err := fillFiltersItem(filters, rFilterItemMap, definitions); err != nil {
+		if err := fillFiltersItem(filters, rFilterItemMap, ctx); err != nil {
 			return err
 		}
 	}
@@ -212,8 +246,8 @@ func fillFiltersItems(filters *[]*Filter, rFilterItems []any, definitions *Defin
 	return nil
 }
 
-func fillFiltersItem(filters *[]*Filter, rFilterItem map[string]any, definitions *Definitions) error {
-	newFilters, err := createFilters(definitions, rFilterItem)
+func fillFiltersItem(filters *[]*Filter, rFilterItem map[string]any, ctx *parseCtx) error {
+	newFilters, err := createFilters(rFilterItem, ctx)
 	if err != nil {
 		return err
 	}
@@ -223,14 +257,14 @@ func fillFiltersItem(filters *[]*Filter, rFilterItem map[string]any, definitions
 	return nil
 }
 
-func fillConditionsMap(conditionsMap *map[string][]*Condition, rConditions map[string]any, definitions *Definitions) error {
+func fillConditionsMap(conditionsMap *map[string][]*Condition, rConditions map[string]any, ctx *parseCtx) error {
 	for conditionName, rConditionItems := range rConditions {
 		rConditionItemsSlice, ok := rConditionItems.([]any)
 		if !ok {
 			return errInvalidInputReason(fmt.Sprintf("each condition must be an array of condition items (%s is not)", conditionName))
 		}
 		var conditionsList []*Condition
-		if err := fillConditionsItems(&conditionsList, rConditionItemsSlice, definitions); err != nil {
+		if err := fillConditionsItems(&conditionsList, rConditionItemsSlice, ctx); err != nil {
 			return err
 		}
 		(*conditionsMap)[conditionName] = conditionsList
@@ -239,7 +273,7 @@ func fillConditionsMap(conditionsMap *map[string][]*Condition, rConditions map[s
 	return nil
 }
 
-func fillConditionsItems(conditions *[]*Condition, rConditionItems []any, definitions *Definitions) error {
+func fillConditionsItems(conditions *[]*Condition, rConditionItems []any, ctx *parseCtx) error {
 	if (len(rConditionItems)) == 0 {
 		return errInvalidInputReason("each condition must have at least one item")
 	}
@@ -249,7 +283,7 @@ func fillConditionsItems(conditions *[]*Condition, rConditionItems []any, defini
 		if !ok {
 			return errInvalidInputReason("each condition item must be an object")
 		}
-		if err := fillConditionsItem(conditions, rConditionItemMap, definitions); err != nil {
+		if err := fillConditionsItem(conditions, rConditionItemMap, ctx); err != nil {
 			return err
 		}
 	}
@@ -257,8 +291,8 @@ func fillConditionsItems(conditions *[]*Condition, rConditionItems []any, defini
 	return nil
 }
 
-func fillConditionsItem(conditions *[]*Condition, rConditionItem map[string]any, definitions *Definitions) error {
-	newConditions, err := createConditions(definitions, rConditionItem)
+func fillConditionsItem(conditions *[]*Condition, rConditionItem map[string]any, ctx *parseCtx) error {
+	newConditions, err := createConditions(rConditionItem, ctx)
 	if err != nil {
 		return err
 	}
@@ -268,14 +302,14 @@ func fillConditionsItem(conditions *[]*Condition, rConditionItem map[string]any,
 	return nil
 }
 
-func fillResponsesMap(responsesMap *map[string]*Response, rResponses map[string]any, definitions *Definitions) error {
+func fillResponsesMap(responsesMap *map[string]*Response, rResponses map[string]any, ctx *parseCtx) error {
 	for responseName, rResponse := range rResponses {
 		rResponseMap, ok := rResponse.(map[string]any)
 		if !ok {
 			return errInvalidInputReason("each response must be an object")
 		}
 		var response Response
-		if err := fillResponseItem(&response, rResponseMap, definitions); err != nil {
+		if err := fillResponseItem(&response, rResponseMap, ctx); err != nil {
 			return err
 		}
 		(*responsesMap)[responseName] = &response
@@ -284,8 +318,8 @@ func fillResponsesMap(responsesMap *map[string]*Response, rResponses map[string]
 	return nil
 }
 
-func fillResponseItem(response *Response, rResponse map[string]any, definitions *Definitions) error {
-	newResponse, err := createResponse(rResponse, definitions)
+func fillResponseItem(response *Response, rResponse map[string]any, ctx *parseCtx) error {
+	newResponse, err := createResponse(rResponse, ctx)
 	if err != nil {
 		return err
 	}
@@ -295,13 +329,13 @@ func fillResponseItem(response *Response, rResponse map[string]any, definitions
 	return nil
 }
 
-func fillEndpoints(endpoints *[]*Endpoint, rEndpoints []any, definitions *Definitions) error {
+func fillEndpoints(endpoints *[]*Endpoint, rEndpoints []any, ctx *parseCtx) error {
 	for _, rEndpoint := range rEndpoints {
 		rEndpointMap, ok := rEndpoint.(map[string]any)
 		if !ok {
 			return errInvalidInputReason("each endpoint must be an object")
 		}
-		endpoint, err := createEndpoint(rEndpointMap, definitions)
+		endpoint, err := createEndpoint(rEndpointMap, ctx)
 		if err != nil {
 			return err
 		}
@@ -310,131 +344,146 @@ func fillEndpoints(endpoints *[]*Endpoint, rEndpoints []any, definitions *Defini
 	return nil
 }
 
-func createSteps(rStepItem map[string]any, definitions *Definitions) ([]*Step, error) {
-	var steps []*Step
-	var err error
+// stepShape is a step item as written in YAML: a single-key object whose
+// key is the operation name and whose value is either a parameter object
+// or a bare scalar (normalized into {"value": scalar} by the decode hook).
+// Since the operation name is the key itself rather than a fixed field,
+// it can't be decoded with the rest of Step in one pass.
+type stepShape struct {
+	Parameters map[string]any
+}
 
+func createSteps(rStepItem map[string]any, ctx *parseCtx) ([]*Step, error) {
 	if len(rStepItem) > 1 {
 		return nil, errObjectMustHaveSingleKey
 	}
 
-	refName, ok, err := extractString(rStepItem, "$ref")
-	if err != nil {
-		return nil, err
-	}
-	if ok {
-		return getStepsByReference(definitions, refName)
-	}
+	var steps []*Step
 
 	for rStepOperation, rStepParams := range rStepItem {
-		step := Step{
-			Operation: rStepOperation,
-		}
-		switch rStepParams.(type) {
-		case map[string]any:
-			step.Parameters = rStepParams.(map[string]any)
-		default:
-			step.Parameters = map[string]any{
-				"value": rStepParams,
-			}
+		if rStepOperation == "$ref" {
+			refName, _ := rStepParams.(string)
+			return getStepsByReference(refName, ctx)
+		}
+
+		var shape stepShape
+		if err := decode(map[string]any{"parameters": rStepParams}, &shape, false); err != nil {
+			return nil, err
 		}
-		steps = append(steps, &step)
+
+		steps = append(steps, &Step{
+			Operation:  rStepOperation,
+			Parameters: shape.Parameters,
+		})
 	}
 
 	return steps, nil
 }
 
-func createFilters(definitions *Definitions, rFilterItem map[string]any) ([]*Filter, error) {
-	refName, ok, err := extractString(rFilterItem, "$ref")
-	if err != nil {
+type filterShape struct {
+	Ref    string `mapstructure:"$ref"`
+	Source string
+	Target string
+	Steps  []any
+}
+
+func createFilters(rFilterItem map[string]any, ctx *parseCtx) ([]*Filter, error) {
+	var shape filterShape
+	if err := decode(rFilterItem, &shape, ctx.strict); err != nil {
 		return nil, err
 	}
-	if ok {
-		return getFiltersByReference(definitions, refName)
-	}
 
-	filter := Filter{}
-	if err = fillStrings(map[string]*string{
-		"source": &filter.Source,
-		"target": &filter.Target,
-	}, rFilterItem); err != nil {
-		return nil, err
+	if shape.Ref != "" {
+		return getFiltersByReference(shape.Ref, ctx)
 	}
+
+	filter := Filter{Source: shape.Source, Target: shape.Target}
 	if filter.Source == "" {
 		return nil, errInvalidInputReason("filter must have a source")
 	}
 
-	rSteps, err := extractSliceOfAny(rFilterItem, "steps")
-	if err != nil {
+	var err error
+	if filter.SourceSelector, err = parseSelector(filter.Source); err != nil {
 		return nil, err
 	}
-	if rSteps != nil {
-		for _, rStep := range rSteps {
-			rStepMap, ok := rStep.(map[string]any)
-			if !ok {
-				return nil, errInvalidInputReason("each step item must be an object")
-			}
-			steps, err := createSteps(rStepMap, definitions)
-			if err != nil {
-				return nil, err
-			}
-			for _, step := range steps {
-				filter.Steps = append(filter.Steps, step)
-			}
+	if filter.Target != "" {
+		if filter.TargetSelector, err = parseSelector(filter.Target); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, rStep := range shape.Steps {
+		rStepMap, ok := rStep.(map[string]any)
+		if !ok {
+			return nil, errInvalidInputReason("each step item must be an object")
+		}
+		steps, err := createSteps(rStepMap, ctx)
+		if err != nil {
+			return nil, err
 		}
+		filter.Steps = append(filter.Steps, steps...)
 	}
 
 	return []*Filter{&filter}, nil
 }
 
-func createConditions(definitions *Definitions, rConditionItem map[string]any) ([]*Condition, error) {
-	refName, ok, err := extractString(rConditionItem, "$ref")
-	if err != nil {
+type conditionShape struct {
+	Ref        string `mapstructure:"$ref"`
+	Expression string
+	Any        []any
+	All        []any
+	Source     string
+	Checks     []any
+}
+
+func createConditions(rConditionItem map[string]any, ctx *parseCtx) ([]*Condition, error) {
+	var shape conditionShape
+	if err := decode(rConditionItem, &shape, ctx.strict); err != nil {
 		return nil, err
 	}
-	if ok {
-		return getConditionsByReference(definitions, refName)
+
+	if shape.Ref != "" {
+		return getConditionsByReference(shape.Ref, ctx)
+	}
+
+	if shape.Expression != "" {
+		condition, err := parseConditionExpression(shape.Expression, ctx.definitions)
+		if err != nil {
+			return nil, err
+		}
+		return []*Condition{condition}, nil
 	}
 
 	condition := Condition{}
 
-	sliceAny, err := extractSliceOfAny(rConditionItem, "any")
-	if err != nil {
-		return nil, err
-	}
-	if sliceAny != nil {
-		if err := fillConditionsItems(&condition.Any, sliceAny, definitions); err != nil {
+	if shape.Any != nil {
+		if err := fillConditionsItems(&condition.Any, shape.Any, ctx); err != nil {
 			return nil, err
 		}
 		return []*Condition{&condition}, nil
 	}
 
-	sliceAll, err := extractSliceOfAny(rConditionItem, "all")
-	if err != nil {
-		return nil, err
-	}
-	if sliceAll != nil {
-		if err := fillConditionsItems(&condition.All, sliceAll, definitions); err != nil {
+	if shape.All != nil {
+		if err := fillConditionsItems(&condition.All, shape.All, ctx); err != nil {
 			return nil, err
 		}
 		return []*Condition{&condition}, nil
 	}
 
-	if err = fillString(&condition.Source, rConditionItem, "source"); err != nil {
-		return nil, err
-	}
+	condition.Source = shape.Source
 	if condition.Source == "" {
 		return nil, errInvalidInputReason("condition must have a source")
 	}
 
-	rChecks, err := extractSliceOfAny(rConditionItem, "checks")
-	if err != nil {
+	var err error
+	if condition.SourceSelector, err = parseSelector(condition.Source); err != nil {
 		return nil, err
 	}
-	if rChecks == nil {
+
+	if shape.Checks == nil {
 		return nil, errInvalidInputReason("checks must be an array")
 	}
-	condition.Checks, err = createChecks(rChecks)
+	condition.Checks, err = createChecks(shape.Checks)
 	if err != nil {
 		return nil, err
 	}
@@ -442,6 +491,12 @@ func createConditions(definitions *Definitions, rConditionItem map[string]any) (
 	return []*Condition{&condition}, nil
 }
 
+// checkShape mirrors stepShape: a check item is a single-key object
+// whose key is the check name.
+type checkShape struct {
+	Parameters map[string]any
+}
+
 func createChecks(rChecks []any) ([]*Check, error) {
 	var checks []*Check
 
@@ -454,119 +509,101 @@ func createChecks(rChecks []any) ([]*Check, error) {
 			return nil, errObjectMustHaveSingleKey
 		}
 		for rCheckName, rCheckParams := range rCheckMap {
-			check := Check{
-				Name: rCheckName,
-			}
-			switch rCheckParams.(type) {
-			case map[string]any:
-				check.Parameters = rCheckParams.(map[string]any)
-			default:
-				check.Parameters = map[string]any{
-					"value": rCheckParams,
-				}
+			var shape checkShape
+			if err := decode(map[string]any{"parameters": rCheckParams}, &shape, false); err != nil {
+				return nil, err
 			}
-			checks = append(checks, &check)
+			checks = append(checks, &Check{
+				Name:       rCheckName,
+				Parameters: shape.Parameters,
+			})
 		}
 	}
 
 	return checks, nil
 }
 
-func createResponse(rResponse map[string]any, definitions *Definitions) (*Response, error) {
-	var response Response
-
-	refName, ok, err := extractString(rResponse, "$ref")
-	if err != nil {
-		return nil, err
-	}
-	if ok {
-		return getResponseByReference(definitions, refName)
-	}
-
-	if err := fillStrings(map[string]*string{
-		"format": &response.Format,
-		"body":   &response.Body,
-	}, rResponse); err != nil {
-		return nil, err
-	}
+type responseShape struct {
+	Ref     string `mapstructure:"$ref"`
+	Format  string
+	Body    string
+	Status  int
+	Headers map[string][]string
+}
 
-	if err := fillInt(&response.Status, rResponse, "status"); err != nil {
+func createResponse(rResponse map[string]any, ctx *parseCtx) (*Response, error) {
+	var shape responseShape
+	if err := decode(rResponse, &shape, ctx.strict); err != nil {
 		return nil, err
 	}
 
-	rHeaders, err := extractStringMap(rResponse, "headers")
-	if err != nil {
-		return nil, err
+	if shape.Ref != "" {
+		return getResponseByReference(shape.Ref, ctx)
 	}
-	if rHeaders != nil {
-		headers := map[string][]string{}
-		for headerName, headerValues := range rHeaders {
-			headers[headerName] = []string{}
 
-			headerValuesSlice, ok := headerValues.([]any)
-			if !ok {
-				return nil, errInvalidInputReason("each header values must be an array of strings")
-			}
-
-			for _, headerValue := range headerValuesSlice {
-				headers[headerName] = append(headers[headerName], headerValue.(string))
-			}
-		}
-	}
-
-	return &response, nil
+	return &Response{
+		Format:  shape.Format,
+		Body:    shape.Body,
+		Status:  shape.Status,
+		Headers: shape.Headers,
+	}, nil
 }
 
-func createEndpoint(rEndpoint map[string]any, definitions *Definitions) (*Endpoint, error) {
-	var endpoint Endpoint
+// endpointShape mirrors the top-level keys of an endpoint object.
+// Filters, Conditions, Endpoints, and Response are left as any/[]any
+// because they need ctx-aware handling (selectors, $ref resolution,
+// recursion) rather than a plain decode.
+type endpointShape struct {
+	Description string
+	Host        string
+	Method      string
+	Path        string
+	BodyFormat  string
+	Filters     []any
+	Conditions  []any
+	Endpoints   []any
+	Response    any
+}
 
-	if err := fillStrings(map[string]*string{
-		"description": &endpoint.Description,
-		"host":        &endpoint.Host,
-		"method":      &endpoint.Method,
-		"path":        &endpoint.Path,
-		"bodyFormat":  &endpoint.BodyFormat,
-	}, rEndpoint); err != nil {
+func createEndpoint(rEndpoint map[string]any, ctx *parseCtx) (*Endpoint, error) {
+	var shape endpointShape
+	if err := decode(rEndpoint, &shape, ctx.strict); err != nil {
 		return nil, err
 	}
 
-	rFilterItems, err := extractSliceOfAny(rEndpoint, "filters")
-	if err != nil {
-		return nil, err
+	endpoint := Endpoint{
+		Description: shape.Description,
+		Host:        shape.Host,
+		Method:      shape.Method,
+		Path:        shape.Path,
+		BodyFormat:  shape.BodyFormat,
 	}
-	if rFilterItems != nil {
-		if err := fillFiltersItems(&endpoint.Filters, rFilterItems, definitions); err != nil {
+
+	if shape.Filters != nil {
+		if err := fillFiltersItems(&endpoint.Filters, shape.Filters, ctx); err != nil {
 			return nil, err
 		}
 	}
 
-	rConditionItems, err := extractSliceOfAny(rEndpoint, "conditions")
-	if err != nil {
-		return nil, err
-	}
-	if rConditionItems != nil {
-		if err := fillConditionsItems(&endpoint.Conditions, rConditionItems, definitions); err != nil {
+	if shape.Conditions != nil {
+		if err := fillConditionsItems(&endpoint.Conditions, shape.Conditions, ctx); err != nil {
 			return nil, err
 		}
 	}
 
-	rEndpointItems, err := extractSliceOfAny(rEndpoint, "endpoints")
-	if err != nil {
-		return nil, err
-	}
-	if rEndpointItems != nil {
-		if err := fillEndpoints(&endpoint.Endpoints, rEndpointItems, definitions); err != nil {
+	if shape.Endpoints != nil {
+		if err := fillEndpoints(&endpoint.Endpoints, shape.Endpoints, ctx); err != nil {
 			return nil, err
 		}
 	}
 
-	rResponse, err := extractStringMap(rEndpoint, "response")
-	if err != nil {
-		return nil, err
-	}
-	if rResponse != nil {
+	if shape.Response != nil {
+		rResponse, ok := shape.Response.(map[string]any)
+		if !ok {
+			return nil, errInvalidInputReason("expected 'response' to be an object")
+		}
 		var response Response
-		if err := fillResponseItem(&response, rResponse, definitions); err != nil {
+		if err := fillResponseItem(&response, rResponse, ctx); err != nil {
 			return nil, err
 		}
 		endpoint.Response = &response
@@ -579,131 +616,107 @@ func createEndpoint(rEndpoint map[string]any, definitions *Definitions) (*Endpoi
 	return &endpoint, nil
 }
 
-func getStepsByReference(definitions *Definitions, refName string) ([]*Step, error) {
-	steps, ok := definitions.Steps[refName]
-	if !ok {
-		return nil, errUnknownDefinitionWithName("steps", refName)
+// resolveRefDefinitions returns the Definitions a "$ref" value should be
+// looked up against: ctx's own definitions when refName is a bare name
+// or a fragment-only reference ("#/definitions/<group>/<name>"), or the
+// Definitions of the external document named by its URI otherwise.
+func resolveRefDefinitions(refName, group string, ctx *parseCtx) (*Definitions, string, error) {
+	uri, name, err := parseRef(refName, group)
+	if err != nil {
+		return nil, "", err
 	}
-
-	return steps, nil
-}
-
-func getFiltersByReference(definitions *Definitions, refName string) ([]*Filter, error) {
-	filters, ok := definitions.Filters[refName]
-	if !ok {
-		return nil, errUnknownDefinitionWithName("filters", refName)
+	if uri == "" {
+		return ctx.definitions, name, nil
 	}
 
-	return filters, nil
-}
-
-func getConditionsByReference(definitions *Definitions, refName string) ([]*Condition, error) {
-	conditions, ok := definitions.Conditions[refName]
-	if !ok {
-		return nil, errUnknownDefinitionWithName("conditions", refName)
+	spec, err := ctx.resolver.resolve(uri)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return conditions, nil
+	return spec.Definitions, name, nil
 }
 
-func getResponseByReference(definitions *Definitions, refName string) (*Response, error) {
-	response, ok := definitions.Responses[refName]
-	if !ok {
-		return nil, errUnknownDefinitionWithName("responses", refName)
+// parseRef splits a "$ref" value into an optional URI and the
+// definition name it targets. A bare name ("foo") is treated as a local
+// reference for back-compat. A JSON Reference ("foo.yaml#/definitions/
+// steps/bar" or just "#/definitions/steps/bar") is split into its URI
+// (possibly empty, meaning "this document") and name, validated against
+// the expected group (steps, filters, conditions, or responses).
+func parseRef(refName, group string) (uri, name string, err error) {
+	hashIndex := strings.Index(refName, "#")
+	if hashIndex == -1 {
+		return "", refName, nil
 	}
 
-	return response, nil
-}
+	uri = refName[:hashIndex]
+	fragment := refName[hashIndex+1:]
 
-func extractString(input map[string]any, name string) (string, bool, error) {
-	value, ok := input[name]
-	if !ok {
-		return "", false, nil
+	prefix := "/definitions/" + group + "/"
+	if !strings.HasPrefix(fragment, prefix) {
+		return "", "", errInvalidInputReason(fmt.Sprintf("malformed $ref, expected fragment %s<name>: %s", prefix, refName))
 	}
 
-	str, ok := value.(string)
-	if !ok {
-		return "", false, errInvalidInputReason(fmt.Sprintf("expected '%s' to be a string", name))
+	name = strings.TrimPrefix(fragment, prefix)
+	if name == "" {
+		return "", "", errInvalidInputReason("malformed $ref, missing name: " + refName)
 	}
 
-	return str, true, nil
+	return uri, name, nil
 }
 
-func extractInt(input map[string]any, name string) (int, bool, error) {
-	value, ok := input[name]
-	if !ok {
-		return 0, false, nil
+func getStepsByReference(refName string, ctx *parseCtx) ([]*Step, error) {
+	definitions, name, err := resolveRefDefinitions(refName, "steps", ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	i, ok := value.(int)
+	steps, ok := definitions.Steps[name]
 	if !ok {
-		return 0, false, errInvalidInputReason(fmt.Sprintf("expect '%s' to be an integer", name))
+		return nil, errUnknownDefinitionWithName("steps", name)
 	}
 
-	return i, true, nil
+	return steps, nil
 }
 
-func fillString(target *string, input map[string]any, name string) error {
-	value, ok, err := extractString(input, name)
+func getFiltersByReference(refName string, ctx *parseCtx) ([]*Filter, error) {
+	definitions, name, err := resolveRefDefinitions(refName, "filters", ctx)
 	if err != nil {
-		return err
-	}
-	if !ok {
-		return nil
-	}
-
-	*target = value
-
-	return nil
-}
-
-func fillStrings(targets map[string]*string, input map[string]any) error {
-	for name, target := range targets {
-		if err := fillString(target, input, name); err != nil {
-			return err
-		}
+		return nil, err
 	}
-	return nil
-}
 
-func fillInt(target *int, input map[string]any, name string) error {
-	value, ok, err := extractInt(input, name)
-	if err != nil {
-		return err
-	}
+	filters, ok := definitions.Filters[name]
 	if !ok {
-		return nil
+		return nil, errUnknownDefinitionWithName("filters", name)
 	}
 
-	*target = value
-
-	return nil
+	return filters, nil
 }
 
-func extractStringMap(input map[string]any, name string) (map[string]any, error) {
-	output, ok := input[name]
-	if !ok {
-		return nil, nil
+func getConditionsByReference(refName string, ctx *parseCtx) ([]*Condition, error) {
+	definitions, name, err := resolveRefDefinitions(refName, "conditions", ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	outputMap, ok := output.(map[string]any)
+	conditions, ok := definitions.Conditions[name]
 	if !ok {
-		return nil, errInvalidInputReason(fmt.Sprintf("expected '%s' to be an object", name))
+		return nil, errUnknownDefinitionWithName("conditions", name)
 	}
 
-	return outputMap, nil
+	return conditions, nil
 }
 
-func extractSliceOfAny(input map[string]any, name string) ([]any, error) {
-	output, ok := input[name]
-	if !ok {
-		return nil, nil
+func getResponseByReference(refName string, ctx *parseCtx) (*Response, error) {
+	definitions, name, err := resolveRefDefinitions(refName, "responses", ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	outputSlice, ok := output.([]any)
+	response, ok := definitions.Responses[name]
 	if !ok {
-		return nil, errInvalidInputReason(fmt.Sprintf("expected '%s' to be an array", name))
+		return nil, errUnknownDefinitionWithName("responses", name)
 	}
 
-	return outputSlice, nil
+	return response, nil
 }
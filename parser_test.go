@@ -0,0 +1,69 @@
+package msparser
+
+import "testing"
+
+// Regression test: createResponse used to build a "headers" map and
+// never assign it to Response.Headers.
+func TestResponseHeadersAreAssigned(t *testing.T) {
+	spec, err := Parse([]byte(`
+endpoints:
+  - path: /x
+    method: GET
+    response:
+      status: 200
+      headers:
+        X-Test:
+          - a
+          - b
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	got := spec.Endpoints[0].Response.Headers["X-Test"]
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("Headers[X-Test] = %v, want [a b]", got)
+	}
+}
+
+// Regression test: the hand-rolled extractInt only accepted Go's plain
+// "int", so a YAML number that decodes as int64/float64 (e.g. written
+// in exponential form) failed to parse.
+func TestResponseStatusAcceptsWideNumericTypes(t *testing.T) {
+	spec, err := Parse([]byte(`
+endpoints:
+  - path: /x
+    method: GET
+    response:
+      status: 2.0e2
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if got := spec.Endpoints[0].Response.Status; got != 200 {
+		t.Fatalf("Status = %d, want 200", got)
+	}
+}
+
+// ParseOptions.Strict rejects an unrecognized key instead of silently
+// ignoring it, via decode()'s mapstructure.Metadata.Unused.
+func TestStrictRejectsUnknownField(t *testing.T) {
+	input := []byte(`
+endpoints:
+  - path: /x
+    method: GET
+    respnose:
+      status: 200
+    response:
+      status: 200
+`)
+
+	if _, err := ParseWithOptions(input, ParseOptions{Strict: true}); err == nil {
+		t.Fatal("expected an error for the unrecognized 'respnose' field")
+	}
+
+	if _, err := ParseWithOptions(input, ParseOptions{}); err != nil {
+		t.Fatalf("ParseWithOptions without Strict: %v, want the unknown field to be ignored", err)
+	}
+}
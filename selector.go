@@ -0,0 +1,69 @@
+package msparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var validSelectorRoots = map[string]bool{
+	"path":    true,
+	"query":   true,
+	"headers": true,
+	"body":    true,
+}
+
+// ParseSelector parses a dot-chained source/target string (e.g.
+// "body.items.*.id") into a [Selector], the same way Parse does for a
+// Filter's or Condition's own Source/Target. It's exported so other
+// packages that build a Spec programmatically (e.g. an OpenAPI
+// importer) can populate SourceSelector/TargetSelector themselves
+// instead of leaving a Source string with no parsed form alongside it.
+func ParseSelector(raw string) (*Selector, error) {
+	return parseSelector(raw)
+}
+
+// parseSelector parses a dot-chained source/target string (e.g.
+// "body.items.*.id") into a [Selector]. The first segment must be one
+// of the known parameter roots (path, query, headers, body); later
+// segments are field names, unless they are all-digit (an array index)
+// or exactly "*" (a wildcard over every element of an array).
+func parseSelector(raw string) (*Selector, error) {
+	if raw == "" {
+		return nil, errInvalidInputReason("selector must not be empty")
+	}
+	if strings.HasPrefix(raw, ".") || strings.HasSuffix(raw, ".") {
+		return nil, errInvalidInputReason(fmt.Sprintf("selector must not start or end with '.': %s", raw))
+	}
+
+	parts := strings.Split(raw, ".")
+	for _, part := range parts {
+		if part == "" {
+			return nil, errInvalidInputReason(fmt.Sprintf("selector has an empty segment: %s", raw))
+		}
+	}
+
+	root := parts[0]
+	if !validSelectorRoots[root] {
+		return nil, errInvalidInputReason(fmt.Sprintf("selector has an unknown root %q (must be one of path, query, headers, body): %s", root, raw))
+	}
+
+	var path []Segment
+	for _, part := range parts[1:] {
+		path = append(path, parseSegment(part))
+	}
+
+	return &Selector{Root: root, Path: path}, nil
+}
+
+func parseSegment(part string) Segment {
+	if part == "*" {
+		return Segment{Kind: SegmentWildcard}
+	}
+	if strings.Trim(part, "0123456789") == "" {
+		if index, err := strconv.Atoi(part); err == nil {
+			return Segment{Kind: SegmentIndex, Index: index}
+		}
+	}
+	return Segment{Kind: SegmentField, Name: part}
+}
@@ -0,0 +1,41 @@
+package msparser
+
+import "testing"
+
+func TestParseSelectorWildcardAndIndex(t *testing.T) {
+	selector, err := parseSelector("body.items.*.0")
+	if err != nil {
+		t.Fatalf("parseSelector: %v", err)
+	}
+	if selector.Root != "body" {
+		t.Fatalf("Root = %q, want body", selector.Root)
+	}
+
+	want := []Segment{
+		{Kind: SegmentField, Name: "items"},
+		{Kind: SegmentWildcard},
+		{Kind: SegmentIndex, Index: 0},
+	}
+	if len(selector.Path) != len(want) {
+		t.Fatalf("Path = %+v, want %+v", selector.Path, want)
+	}
+	for i, segment := range selector.Path {
+		if segment != want[i] {
+			t.Fatalf("Path[%d] = %+v, want %+v", i, segment, want[i])
+		}
+	}
+}
+
+func TestParseSelectorUnknownRoot(t *testing.T) {
+	if _, err := parseSelector("cookies.session"); err == nil {
+		t.Fatal("expected an error for an unknown selector root")
+	}
+}
+
+func TestParseSelectorRejectsMalformedInput(t *testing.T) {
+	for _, raw := range []string{"", ".body", "body.", "body..id"} {
+		if _, err := parseSelector(raw); err == nil {
+			t.Fatalf("parseSelector(%q): expected an error", raw)
+		}
+	}
+}